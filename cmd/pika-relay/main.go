@@ -1,21 +1,34 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/pprof"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/eventstore/lmdb"
 	"fiatjaf.com/nostr/khatru"
 	"fiatjaf.com/nostr/khatru/blossom"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/sledtools/pika/internal/admin"
+	"github.com/sledtools/pika/internal/blobstore"
+	"github.com/sledtools/pika/internal/cache"
+	"github.com/sledtools/pika/internal/metrics"
+	"github.com/sledtools/pika/internal/proxy"
 )
 
 func main() {
@@ -23,11 +36,16 @@ func main() {
 
 	port := envOr("PORT", "3334")
 	dataDir := envOr("DATA_DIR", "./data")
-	mediaDir := envOr("MEDIA_DIR", "./media")
 	serviceURL := envOr("SERVICE_URL", "http://localhost:"+port)
 
 	os.MkdirAll(dataDir, 0755)
-	os.MkdirAll(mediaDir, 0755)
+
+	// rootCtx is canceled on shutdown so in-flight blob operations get a
+	// chance to notice and abort even if the originating HTTP request
+	// hasn't itself been canceled yet.
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+	var pendingUploads sync.WaitGroup
 
 	relay := khatru.NewRelay()
 
@@ -45,6 +63,21 @@ func main() {
 
 	relay.Negentropy = true
 
+	// Track open websocket connections and their subscription ids so
+	// shutdown can send real NIP-01 CLOSED envelopes and wait for the
+	// connections to actually close.
+	conns := newWSRegistry()
+	relay.OnConnect = append(relay.OnConnect, func(ctx context.Context) {
+		if ws := khatru.GetConnection(ctx); ws != nil {
+			conns.add(ws)
+		}
+	})
+	relay.OnDisconnect = append(relay.OnDisconnect, func(ctx context.Context) {
+		if ws := khatru.GetConnection(ctx); ws != nil {
+			conns.remove(ws)
+		}
+	})
+
 	// Event storage
 	db := &lmdb.LMDBBackend{Path: filepath.Join(dataDir, "relay")}
 	if err := db.Init(); err != nil {
@@ -61,41 +94,172 @@ func main() {
 	bl := blossom.New(relay, serviceURL)
 	bl.Store = blossom.EventStoreBlobIndexWrapper{Store: bdb, ServiceURL: serviceURL}
 
-	bl.StoreBlob = func(ctx context.Context, sha256 string, ext string, body []byte) error {
-		path := filepath.Join(mediaDir, sha256)
-		return os.WriteFile(path, body, 0644)
+	blobs, err := blobstore.New()
+	if err != nil {
+		log.Fatalf("failed to init blob storage: %v", err)
 	}
 
-	bl.LoadBlob = func(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
-		path := filepath.Join(mediaDir, sha256)
-		data, err := os.ReadFile(path)
+	// Moderation: pubkeys banned through the admin API are rejected at
+	// both the event and Blossom upload paths.
+	bans, err := admin.NewBanStore(filepath.Join(dataDir, "bans"))
+	if err != nil {
+		log.Fatalf("failed to init ban store: %v", err)
+	}
+	relay.RejectEvent = append(relay.RejectEvent, func(ctx context.Context, event *nostr.Event) (bool, string) {
+		if reason, banned := bans.Reason(event.PubKey.Hex()); banned {
+			return true, "blocked: " + reason
+		}
+		return false, ""
+	})
+
+	// Rate limiting: a rudimentary per-pubkey fixed-window limiter, off by
+	// default. RATE_LIMIT_EVENTS_PER_MIN=0 (the default) disables it
+	// entirely so this costs nothing on installs that don't set it.
+	if limit := envOrInt("RATE_LIMIT_EVENTS_PER_MIN", 0); limit > 0 {
+		limiter := newRateLimiter(limit, time.Minute)
+		relay.RejectEvent = append(relay.RejectEvent, func(ctx context.Context, event *nostr.Event) (bool, string) {
+			if !limiter.Allow(event.PubKey.Hex()) {
+				metrics.RateLimitHits.Inc()
+				return true, "rate limited"
+			}
+			return false, ""
+		})
+	}
+
+	// Pull-through mirroring: cache misses on blobs or events are fetched
+	// from configured upstream Blossom servers/relays, served immediately,
+	// and persisted locally under a TTL so the next request is a local hit.
+	upstreamRelays := splitCSV(os.Getenv("UPSTREAM_RELAYS"))
+	upstreamBlossom := splitCSV(os.Getenv("UPSTREAM_BLOSSOM"))
+	if len(upstreamRelays) > 0 || len(upstreamBlossom) > 0 {
+		cacheTTL := envOrDuration("CACHE_TTL", 24*time.Hour)
+		cacheMaxBytes := envOrInt64("CACHE_MAX_BYTES", 0)
+
+		sched, err := cache.NewScheduler(filepath.Join(dataDir, "cache-ttl"), cacheTTL, func(kind cache.Kind, key string) {
+			switch kind {
+			case cache.KindEvent:
+				id, err := nostr.IDFromHex(key)
+				if err != nil {
+					log.Printf("cache gc: invalid cached event id %s: %v", key, err)
+					return
+				}
+				if err := db.DeleteEvent(context.Background(), &nostr.Event{ID: id}); err != nil {
+					log.Printf("cache gc: failed to delete expired event %s: %v", key, err)
+				}
+			case cache.KindBlob:
+				if err := blobs.DeleteBlob(context.Background(), key, ""); err != nil {
+					log.Printf("cache gc: failed to delete expired blob %s: %v", key, err)
+				}
+			}
+		})
 		if err != nil {
-			return nil, nil, err
+			log.Fatalf("failed to init cache scheduler: %v", err)
+		}
+		sched.Start(time.Minute)
+		defer sched.Close()
+
+		if len(upstreamRelays) > 0 {
+			querier := &proxy.EventQuerier{Store: db, Upstreams: upstreamRelays, Scheduler: sched}
+			relay.QueryEvents = append(relay.QueryEvents, querier.Query)
+		}
+
+		if len(upstreamBlossom) > 0 {
+			blobs = proxy.NewBlobBackend(blobs, upstreamBlossom, sched, cacheMaxBytes)
 		}
-		return bytes.NewReader(data), nil, nil
 	}
 
+	bl.StoreBlob = func(ctx context.Context, sha256 string, ext string, body []byte) error {
+		ctx, cancel := mergeContext(ctx, rootCtx)
+		defer cancel()
+
+		pendingUploads.Add(1)
+		defer pendingUploads.Done()
+
+		start := time.Now()
+		err := blobs.StoreBlob(ctx, sha256, ext, body)
+		metrics.BlobUploadDuration.Observe(time.Since(start).Seconds())
+		if err == nil {
+			metrics.BlobUploadBytes.Add(float64(len(body)))
+		}
+		return err
+	}
+	bl.LoadBlob = func(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+		ctx, cancel := mergeContext(ctx, rootCtx)
+		defer cancel()
+
+		start := time.Now()
+		rs, u, err := blobs.LoadBlob(ctx, sha256, ext)
+		metrics.BlobDownloadDuration.Observe(time.Since(start).Seconds())
+		if err == nil {
+			if size, ok := seekSize(rs); ok {
+				metrics.BlobDownloadBytes.Add(float64(size))
+			}
+		}
+		return rs, u, err
+	}
 	bl.DeleteBlob = func(ctx context.Context, sha256 string, ext string) error {
-		return os.Remove(filepath.Join(mediaDir, sha256))
+		ctx, cancel := mergeContext(ctx, rootCtx)
+		defer cancel()
+		return blobs.DeleteBlob(ctx, sha256, ext)
 	}
 
+	var maxUploadBytes atomic.Int64
+	maxUploadBytes.Store(int64(envOrInt("MAX_UPLOAD_STREAM_BYTES", 100*1024*1024)))
 	bl.RejectUpload = func(ctx context.Context, auth *nostr.Event, size int, ext string) (bool, string, int) {
-		if size > 100*1024*1024 {
-			return true, "file too large (100MB max)", 413
+		if reason, banned := bans.Reason(auth.PubKey.Hex()); banned {
+			return true, "blocked: " + reason, 403
+		}
+		if limit := maxUploadBytes.Load(); int64(size) > limit {
+			return true, fmt.Sprintf("file too large (%d bytes max)", limit), 413
 		}
 		return false, "", 0
 	}
 
+	// Event/subscription accounting. This wraps whatever RejectEvent and
+	// QueryEvents hooks earlier setup (including any added above for
+	// pull-through mirroring) has already registered, so it must stay the
+	// last thing to touch those hook chains.
+	instrumentEventAccounting(relay, conns)
+
+	diskStop := make(chan struct{})
+	go metrics.SampleDiskUsage("data", dataDir, time.Minute, diskStop)
+	go metrics.SampleDiskUsage("media", envOr("MEDIA_DIR", "./media"), time.Minute, diskStop)
+
 	// Health check
 	mux := relay.Router()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{"status":"ok"}`))
 	})
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if adminPubkeys := splitCSV(os.Getenv("ADMIN_PUBKEYS")); len(adminPubkeys) > 0 {
+		adminSrv := admin.New(db, blobs, bans, adminPubkeys)
+		adminSrv.Mount(mux)
+	}
+
+	if envOr("ENABLE_PPROF", "false") == "true" {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
 
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			relay.Info.Name = envOr("RELAY_NAME", "pika-relay")
+			relay.Info.Description = envOr("RELAY_DESCRIPTION", "Pika relay + Blossom media server")
+			maxUploadBytes.Store(int64(envOrInt("MAX_UPLOAD_STREAM_BYTES", 100*1024*1024)))
+			log.Println("reloaded relay name/description/limits from environment (SIGHUP)")
+		}
+	}()
+
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: relay,
@@ -110,7 +274,49 @@ func main() {
 
 	<-shutdown
 	log.Println("shutting down...")
-	srv.Shutdown(context.Background())
+
+	// Stop accepting new work.
+	cancelRoot()
+
+	shutdownTimeout := envOrDuration("SHUTDOWN_TIMEOUT", 10*time.Second)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+
+	// Tell every open subscription its connection is going away with a real
+	// CLOSED (not just a generic NOTICE), then force the connections closed
+	// and wait for that to actually land - a slow or non-compliant client
+	// must not keep db/bdb open past shutdown just by never reading its
+	// socket.
+	conns.notifyShutdown()
+	conns.closeAll()
+	conns.wait(shutdownCtx)
+
+	uploadsDone := make(chan struct{})
+	go func() {
+		pendingUploads.Wait()
+		close(uploadsDone)
+	}()
+	select {
+	case <-uploadsDone:
+	case <-shutdownCtx.Done():
+		log.Println("shutdown timeout reached before all pending uploads finished")
+	}
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
+
+	close(diskStop)
+
+	if err := db.Close(); err != nil {
+		log.Printf("failed to close relay db: %v", err)
+	}
+	if err := bdb.Close(); err != nil {
+		log.Printf("failed to close blossom db: %v", err)
+	}
+	if err := bans.Close(); err != nil {
+		log.Printf("failed to close ban store: %v", err)
+	}
 }
 
 func envOr(key, fallback string) string {
@@ -120,4 +326,279 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
+func envOrInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func envOrInt64(key string, fallback int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func envOrDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// mergeContext derives a context that is canceled when either parent or
+// shutdown is done, so a long-running blob operation started from a
+// request-scoped ctx still notices a server shutdown.
+func mergeContext(parent, shutdown context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-shutdown.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// wsRegistry tracks currently open websocket connections, and the
+// subscription ids each one has open, so shutdown can send real NIP-01
+// CLOSED envelopes and then wait for those connections to actually go away
+// instead of merely notifying them.
+type wsRegistry struct {
+	mu   sync.Mutex
+	subs map[*khatru.WebSocket]map[string]struct{}
+}
+
+func newWSRegistry() *wsRegistry {
+	return &wsRegistry{subs: make(map[*khatru.WebSocket]map[string]struct{})}
+}
+
+func (r *wsRegistry) add(ws *khatru.WebSocket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[ws]; !ok {
+		r.subs[ws] = make(map[string]struct{})
+	}
+}
+
+func (r *wsRegistry) remove(ws *khatru.WebSocket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subs, ws)
+}
+
+// trackSubscription records that ws has subID open, so notifyShutdown can
+// send it a real CLOSED rather than a connection-level NOTICE. There's no
+// hook into a client sending its own CLOSE for subID, so an entry only
+// ever goes away when its whole connection disconnects - harmless, since
+// by then there's nothing left to notify anyway.
+func (r *wsRegistry) trackSubscription(ws *khatru.WebSocket, subID string) {
+	if ws == nil || subID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[ws]; !ok {
+		r.subs[ws] = make(map[string]struct{})
+	}
+	r.subs[ws][subID] = struct{}{}
+}
+
+// notifyShutdown sends a NIP-01 CLOSED to every subscription id we've
+// tracked for a connection, or a generic NOTICE to connections we haven't
+// seen a subscription id for yet, ahead of closeAll tearing them down.
+func (r *wsRegistry) notifyShutdown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ws, subIDs := range r.subs {
+		if len(subIDs) == 0 {
+			ws.WriteJSON(nostr.NoticeEnvelope("relay shutting down"))
+			continue
+		}
+		for subID := range subIDs {
+			ws.WriteJSON(nostr.ClosedEnvelope{SubscriptionID: subID, Reason: "relay shutting down"})
+		}
+	}
+}
+
+// closeAll force-closes every tracked connection so a slow or
+// non-compliant client can't keep one open past shutdown.
+func (r *wsRegistry) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ws := range r.subs {
+		ws.Close()
+	}
+}
+
+func (r *wsRegistry) empty() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subs) == 0
+}
+
+// wait blocks until every tracked connection has disconnected (removed via
+// OnDisconnect) or ctx is done, whichever comes first.
+func (r *wsRegistry) wait(ctx context.Context) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for !r.empty() {
+		select {
+		case <-ctx.Done():
+			log.Println("shutdown timeout reached before all websocket connections closed")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rateLimiter is a rudimentary fixed-window per-pubkey event rate limiter:
+// each pubkey gets a budget of limit events per window, reset the first
+// time it's touched after the window has elapsed.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	counts map[string]*rateLimitWindow
+}
+
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, counts: make(map[string]*rateLimitWindow)}
+}
+
+// Allow reports whether pubkey is still within its budget for the current
+// window, counting this call toward that budget either way.
+func (r *rateLimiter) Allow(pubkey string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	w, ok := r.counts[pubkey]
+	if !ok || now.Sub(w.start) >= r.window {
+		w = &rateLimitWindow{start: now}
+		r.counts[pubkey] = w
+	}
+	w.count++
+	return w.count <= r.limit
+}
+
+// seekSize reports the total size of rs without consuming it, restoring
+// its original read position afterwards.
+func seekSize(rs io.ReadSeeker) (int64, bool) {
+	cur, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := rs.Seek(cur, io.SeekStart); err != nil {
+		return 0, false
+	}
+	return end, true
+}
+
+// instrumentEventAccounting wraps relay's current RejectEvent and
+// QueryEvents hook chains with accept/reject-by-kind counters, an active
+// subscription gauge, a REQ latency histogram, and - via conns - records
+// each subscription's id against its connection so shutdown can send it a
+// real CLOSED. It replaces each slice wholesale, so any hooks appended
+// afterwards won't be accounted for.
+func instrumentEventAccounting(relay *khatru.Relay, conns *wsRegistry) {
+	rejectHooks := relay.RejectEvent
+	relay.RejectEvent = []func(ctx context.Context, event *nostr.Event) (bool, string){
+		func(ctx context.Context, event *nostr.Event) (bool, string) {
+			kind := strconv.Itoa(int(event.Kind))
+			for _, fn := range rejectHooks {
+				if reject, msg := fn(ctx, event); reject {
+					metrics.EventsRejected.WithLabelValues(kind).Inc()
+					return true, msg
+				}
+			}
+			metrics.EventsAccepted.WithLabelValues(kind).Inc()
+			return false, ""
+		},
+	}
+
+	queryHooks := relay.QueryEvents
+	relay.QueryEvents = []func(ctx context.Context, filter nostr.Filter) (chan *nostr.Event, error){
+		func(ctx context.Context, filter nostr.Filter) (chan *nostr.Event, error) {
+			start := time.Now()
+			metrics.ActiveSubscriptions.Inc()
+
+			if ws := khatru.GetConnection(ctx); ws != nil {
+				conns.trackSubscription(ws, khatru.GetSubscriptionID(ctx))
+			}
+
+			var chans []chan *nostr.Event
+			for _, fn := range queryHooks {
+				ch, err := fn(ctx, filter)
+				if err != nil {
+					continue
+				}
+				chans = append(chans, ch)
+			}
+
+			out := make(chan *nostr.Event)
+			go func() {
+				defer close(out)
+				defer metrics.ActiveSubscriptions.Dec()
+				defer func() { metrics.ReqLatency.Observe(time.Since(start).Seconds()) }()
+
+				for evt := range mergeEventChannels(chans...) {
+					out <- evt
+				}
+			}()
+			return out, nil
+		},
+	}
+}
+
+func mergeEventChannels(chans ...chan *nostr.Event) chan *nostr.Event {
+	out := make(chan *nostr.Event)
+	var wg sync.WaitGroup
+	wg.Add(len(chans))
+	for _, c := range chans {
+		go func(c chan *nostr.Event) {
+			defer wg.Done()
+			for evt := range c {
+				out <- evt
+			}
+		}(c)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// splitCSV splits a comma-separated env var into a trimmed, non-empty list.
+func splitCSV(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/PowerDNS/lmdb-go/lmdb"
+)
+
+// BanStore persists banned pubkeys and their reasons to LMDB. It is
+// consulted by the relay's RejectEvent/RejectUpload hooks to enforce bans
+// issued through the admin API.
+type BanStore struct {
+	env *lmdb.Env
+	dbi lmdb.DBI
+}
+
+type banRecord struct {
+	Reason string `json:"reason"`
+	At     int64  `json:"at"`
+}
+
+// NewBanStore opens (creating if necessary) an LMDB environment at path
+// to persist bans.
+func NewBanStore(path string) (*BanStore, error) {
+	env, err := lmdb.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := env.SetMapSize(1 << 26); err != nil { // 64MB, plenty for ban records
+		return nil, err
+	}
+	if err := env.Open(path, 0, 0644); err != nil {
+		return nil, fmt.Errorf("opening ban store at %s: %w", path, err)
+	}
+
+	var dbi lmdb.DBI
+	err = env.Update(func(txn *lmdb.Txn) error {
+		var err error
+		dbi, err = txn.CreateDBI("bans")
+		return err
+	})
+	if err != nil {
+		env.Close()
+		return nil, err
+	}
+
+	return &BanStore{env: env, dbi: dbi}, nil
+}
+
+// Ban records pubkey as banned with reason, persisted immediately.
+func (b *BanStore) Ban(pubkey, reason string, at int64) error {
+	data, err := json.Marshal(banRecord{Reason: reason, At: at})
+	if err != nil {
+		return err
+	}
+	return b.env.Update(func(txn *lmdb.Txn) error {
+		return txn.Put(b.dbi, []byte(pubkey), data, 0)
+	})
+}
+
+// Unban removes any ban on pubkey.
+func (b *BanStore) Unban(pubkey string) error {
+	return b.env.Update(func(txn *lmdb.Txn) error {
+		err := txn.Del(b.dbi, []byte(pubkey), nil)
+		if lmdb.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Reason reports why pubkey was banned, and whether it is banned at all.
+func (b *BanStore) Reason(pubkey string) (string, bool) {
+	var reason string
+	var found bool
+
+	b.env.View(func(txn *lmdb.Txn) error {
+		data, err := txn.Get(b.dbi, []byte(pubkey))
+		if lmdb.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec banRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		reason, found = rec.Reason, true
+		return nil
+	})
+
+	return reason, found
+}
+
+// List returns every currently banned pubkey and its reason.
+func (b *BanStore) List() (map[string]string, error) {
+	bans := make(map[string]string)
+
+	err := b.env.View(func(txn *lmdb.Txn) error {
+		cur, err := txn.OpenCursor(b.dbi)
+		if err != nil {
+			return err
+		}
+		defer cur.Close()
+
+		for {
+			k, v, err := cur.Get(nil, nil, lmdb.Next)
+			if lmdb.IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			var rec banRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				continue
+			}
+			bans[string(k)] = rec.Reason
+		}
+	})
+
+	return bans, err
+}
+
+func (b *BanStore) Close() error {
+	b.env.Close()
+	return nil
+}
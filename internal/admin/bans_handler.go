@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"fiatjaf.com/nostr"
+)
+
+// handleBans lists (GET), creates (POST), or removes (DELETE) pubkey bans.
+func (s *Server) handleBans(w http.ResponseWriter, r *http.Request, auth *nostr.Event) {
+	switch r.Method {
+	case http.MethodGet:
+		bans, err := s.Bans.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, bans)
+
+	case http.MethodPost:
+		var req struct {
+			Pubkey string `json:"pubkey"`
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Pubkey == "" {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := s.Bans.Ban(req.Pubkey, req.Reason, time.Now().Unix()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		pubkey := r.URL.Query().Get("pubkey")
+		if pubkey == "" {
+			http.Error(w, "missing pubkey query param", http.StatusBadRequest)
+			return
+		}
+		if err := s.Bans.Unban(pubkey); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
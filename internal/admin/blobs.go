@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"fiatjaf.com/nostr"
+
+	"github.com/sledtools/pika/internal/blobstore"
+)
+
+type orphanBlob struct {
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// handleBlobs lists (GET) or deletes (DELETE) orphan blobs - ones not
+// referenced by an "x" or "imeta" tag in any stored event.
+//
+// Orphan detection requires the configured blob backend to implement
+// blobstore.Lister; it returns an error otherwise rather than assuming
+// blobs live on a local filesystem it can walk.
+func (s *Server) handleBlobs(w http.ResponseWriter, r *http.Request, auth *nostr.Event) {
+	if r.URL.Query().Get("orphan") != "true" {
+		http.Error(w, `only orphan listing is supported here; pass ?orphan=true`, http.StatusBadRequest)
+		return
+	}
+
+	orphans, err := s.findOrphanBlobs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, orphans)
+
+	case http.MethodDelete:
+		deleted := 0
+		for _, blob := range orphans {
+			if err := s.Blobs.DeleteBlob(r.Context(), blob.SHA256, ""); err == nil {
+				deleted++
+			}
+		}
+		writeJSON(w, map[string]int{"deleted": deleted})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleBlobByHash deletes a single blob by its sha256, addressed as
+// /admin/blobs/{sha256}.
+func (s *Server) handleBlobByHash(w http.ResponseWriter, r *http.Request, auth *nostr.Event) {
+	sha256 := strings.TrimPrefix(r.URL.Path, "/admin/blobs/")
+	if sha256 == "" {
+		http.Error(w, "missing sha256", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.Blobs.DeleteBlob(r.Context(), sha256, ""); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) findOrphanBlobs(ctx context.Context) ([]orphanBlob, error) {
+	lister, ok := s.Blobs.(blobstore.Lister)
+	if !ok {
+		return nil, fmt.Errorf("configured blob backend does not support listing blobs, so orphan detection isn't available")
+	}
+
+	referenced, err := s.referencedHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := lister.ListBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []orphanBlob
+	for _, blob := range stored {
+		if _, ok := referenced[blob.SHA256]; ok {
+			continue
+		}
+		orphans = append(orphans, orphanBlob{SHA256: blob.SHA256, Size: blob.Size, ModTime: blob.ModTime})
+	}
+	return orphans, nil
+}
+
+// referencedHashes scans every stored event for "x" tags (direct sha256
+// references) and "imeta" tags (NIP-92 style, "x <sha256>" fields).
+func (s *Server) referencedHashes(ctx context.Context) (map[string]struct{}, error) {
+	ch, err := s.Store.QueryEvents(ctx, nostr.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]struct{})
+	for evt := range ch {
+		for _, tag := range evt.Tags {
+			if len(tag) < 2 {
+				continue
+			}
+			switch tag[0] {
+			case "x":
+				hashes[tag[1]] = struct{}{}
+			case "imeta":
+				for _, field := range tag[1:] {
+					if sha256, ok := strings.CutPrefix(field, "x "); ok {
+						hashes[strings.TrimSpace(sha256)] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"fiatjaf.com/nostr"
+)
+
+// handleEvents lists (GET) or deletes (DELETE) every event matching a
+// filter supplied as JSON in the request body, e.g. {"kinds":[1]}.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request, auth *nostr.Event) {
+	var filter nostr.Filter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		http.Error(w, "invalid filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ch, err := s.Store.QueryEvents(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var events []*nostr.Event
+		for evt := range ch {
+			events = append(events, evt)
+		}
+		writeJSON(w, events)
+
+	case http.MethodDelete:
+		deleted := 0
+		for evt := range ch {
+			if err := s.Store.DeleteEvent(r.Context(), evt); err == nil {
+				deleted++
+			}
+		}
+		writeJSON(w, map[string]int{"deleted": deleted})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
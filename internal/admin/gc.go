@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"fiatjaf.com/nostr"
+)
+
+const defaultGCMaxAge = 30 * 24 * time.Hour
+
+// handleGC removes orphan blobs older than ?olderThan= (a Go duration
+// string, default 720h).
+func (s *Server) handleGC(w http.ResponseWriter, r *http.Request, auth *nostr.Event) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxAge := defaultGCMaxAge
+	if v := r.URL.Query().Get("olderThan"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "invalid olderThan duration: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		maxAge = d
+	}
+
+	orphans, err := s.findOrphanBlobs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	deleted := 0
+	for _, blob := range orphans {
+		if blob.ModTime.After(cutoff) {
+			continue
+		}
+		if err := s.Blobs.DeleteBlob(r.Context(), blob.SHA256, ""); err == nil {
+			deleted++
+		}
+	}
+
+	writeJSON(w, map[string]int{"deleted": deleted, "scanned": len(orphans)})
+}
@@ -0,0 +1,116 @@
+// Package admin implements pika's authenticated moderation API, mounted at
+// /admin/* and gated by NIP-98 ("HTTP Auth") signed requests from an
+// allowlist of pubkeys.
+package admin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"fiatjaf.com/nostr"
+)
+
+const (
+	nip98EventKind = 27235
+	nip98MaxSkew   = time.Minute
+)
+
+// authenticate validates the Authorization: Nostr <base64 event> header
+// per NIP-98: the event must be freshly signed, of kind 27235, carry "u"
+// and "method" tags matching this request, and be signed by an
+// allowlisted pubkey.
+func (s *Server) authenticate(r *http.Request) (*nostr.Event, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Nostr ") {
+		return nil, fmt.Errorf("missing Nostr authorization header")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Nostr "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 in authorization header: %w", err)
+	}
+
+	var evt nostr.Event
+	if err := json.Unmarshal(raw, &evt); err != nil {
+		return nil, fmt.Errorf("invalid auth event json: %w", err)
+	}
+
+	if evt.Kind != nip98EventKind {
+		return nil, fmt.Errorf("auth event has wrong kind %d", evt.Kind)
+	}
+	if skew := time.Since(evt.CreatedAt.Time()); skew.Abs() > nip98MaxSkew {
+		return nil, fmt.Errorf("auth event is stale or future-dated")
+	}
+
+	ok, err := evt.CheckSignature()
+	if err != nil || !ok {
+		return nil, fmt.Errorf("auth event has an invalid signature")
+	}
+
+	u := evt.Tags.Find("u")
+	if u == nil || len(u) < 2 || u[1] != requestURL(r) {
+		return nil, fmt.Errorf("u tag does not match request url")
+	}
+	method := evt.Tags.Find("method")
+	if method == nil || len(method) < 2 || !strings.EqualFold(method[1], r.Method) {
+		return nil, fmt.Errorf("method tag does not match request method")
+	}
+
+	if err := s.checkPayload(r, &evt); err != nil {
+		return nil, err
+	}
+
+	if !s.allowed(evt.PubKey) {
+		return nil, fmt.Errorf("pubkey is not in ADMIN_PUBKEYS")
+	}
+
+	return &evt, nil
+}
+
+// checkPayload verifies the NIP-98 "payload" tag (sha256 of the request
+// body) for requests that carry one, so a header observed in transit can't
+// be replayed against the same URL+method with a different body. It
+// restores r.Body afterwards so downstream handlers can still read it.
+func (s *Server) checkPayload(r *http.Request, evt *nostr.Event) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("reading request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	payload := evt.Tags.Find("payload")
+	if payload == nil || len(payload) < 2 {
+		return fmt.Errorf("auth event is missing a payload tag for a request with a body")
+	}
+
+	sum := sha256.Sum256(body)
+	if payload[1] != hex.EncodeToString(sum[:]) {
+		return fmt.Errorf("payload tag does not match request body hash")
+	}
+
+	return nil
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+
+	"github.com/sledtools/pika/internal/blobstore"
+)
+
+// Server implements pika's admin moderation API: listing/deleting events
+// and blobs, banning/unbanning pubkeys, and orphan blob garbage collection.
+type Server struct {
+	Store eventstore.Store
+	Blobs blobstore.Backend
+	Bans  *BanStore
+
+	allowedPubkeys map[string]struct{}
+}
+
+// New builds an admin Server. adminPubkeys are hex-encoded pubkeys allowed
+// to call the admin API (ADMIN_PUBKEYS).
+func New(store eventstore.Store, blobs blobstore.Backend, bans *BanStore, adminPubkeys []string) *Server {
+	allowed := make(map[string]struct{}, len(adminPubkeys))
+	for _, pk := range adminPubkeys {
+		allowed[pk] = struct{}{}
+	}
+	return &Server{Store: store, Blobs: blobs, Bans: bans, allowedPubkeys: allowed}
+}
+
+func (s *Server) allowed(pubkey nostr.PubKey) bool {
+	_, ok := s.allowedPubkeys[pubkey.Hex()]
+	return ok
+}
+
+// Mount registers every admin endpoint on mux, each gated by NIP-98 auth.
+func (s *Server) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/events", s.withAuth(s.handleEvents))
+	mux.HandleFunc("/admin/blobs", s.withAuth(s.handleBlobs))
+	mux.HandleFunc("/admin/blobs/", s.withAuth(s.handleBlobByHash))
+	mux.HandleFunc("/admin/bans", s.withAuth(s.handleBans))
+	mux.HandleFunc("/admin/gc", s.withAuth(s.handleGC))
+}
+
+func (s *Server) withAuth(next func(http.ResponseWriter, *http.Request, *nostr.Event)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		evt, err := s.authenticate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next(w, r, evt)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
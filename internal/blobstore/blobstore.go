@@ -0,0 +1,70 @@
+// Package blobstore provides pluggable storage backends for Blossom media
+// blobs, selected at startup via environment configuration.
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Backend stores and retrieves blob bodies keyed by their SHA-256 hash. Its
+// methods mirror the function-typed hooks (StoreBlob/LoadBlob/DeleteBlob)
+// that khatru/blossom expects, so any Backend can be plugged in directly.
+type Backend interface {
+	StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error
+	LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error)
+	DeleteBlob(ctx context.Context, sha256 string, ext string) error
+}
+
+// BlobInfo describes one stored blob, as reported by a Lister.
+type BlobInfo struct {
+	SHA256  string
+	Size    int64
+	ModTime time.Time
+}
+
+// Lister is an optional Backend capability for backends that can enumerate
+// everything they store. The admin API's orphan blob listing/GC uses this
+// instead of assuming blobs live on a local filesystem it can os.ReadDir.
+type Lister interface {
+	ListBlobs(ctx context.Context) ([]BlobInfo, error)
+}
+
+// New builds a Backend from environment configuration. BLOB_BACKEND selects
+// the underlying storage ("local", "s3", or "gcs"; defaults to "local"). If
+// BLOB_ENCRYPTION_KEY is set, the chosen backend is wrapped so that every
+// blob is sealed at rest with NaCl secretbox.
+func New() (Backend, error) {
+	var backend Backend
+	var err error
+
+	switch kind := envOr("BLOB_BACKEND", "local"); kind {
+	case "local", "":
+		backend, err = newLocalBackend(envOr("MEDIA_DIR", "./media"))
+	case "s3":
+		backend, err = newS3Backend()
+	case "gcs":
+		backend, err = newGCSBackend()
+	default:
+		return nil, fmt.Errorf("unknown BLOB_BACKEND %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key := os.Getenv("BLOB_ENCRYPTION_KEY"); key != "" {
+		return newEncryptedBackend(backend, key)
+	}
+	return backend, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,134 @@
+package blobstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// chunkedDecryptReader implements io.ReadSeeker over an encryptedBackend
+// payload, decrypting one chunkPlaintextSize chunk at a time rather than
+// the whole blob up front. Seeking to a chunk it hasn't visited yet costs a
+// scan of the length prefixes between the furthest chunk seen so far and
+// the target - it never re-reads (or re-decrypts) chunk bodies it doesn't
+// need.
+type chunkedDecryptReader struct {
+	rs        io.ReadSeeker
+	key       *[32]byte
+	baseNonce [nonceLength]byte
+	totalSize int64
+	chunkSize int
+
+	// chunkOffsets[i] is rs's byte offset of chunk i's length prefix.
+	// Extended lazily as chunks beyond what's been scanned so far are
+	// needed.
+	chunkOffsets []int64
+
+	pos      int64
+	curIdx   int
+	curChunk []byte
+	curValid bool
+}
+
+func newChunkedDecryptReader(rs io.ReadSeeker, key *[32]byte, baseNonce [nonceLength]byte, totalSize int64, chunkSize int, chunk0Offset int64) *chunkedDecryptReader {
+	return &chunkedDecryptReader{
+		rs:           rs,
+		key:          key,
+		baseNonce:    baseNonce,
+		totalSize:    totalSize,
+		chunkSize:    chunkSize,
+		chunkOffsets: []int64{chunk0Offset},
+	}
+}
+
+func (c *chunkedDecryptReader) Read(p []byte) (int, error) {
+	if c.pos >= c.totalSize {
+		return 0, io.EOF
+	}
+
+	idx := int(c.pos / int64(c.chunkSize))
+	if !c.curValid || idx != c.curIdx {
+		if err := c.loadChunk(idx); err != nil {
+			return 0, err
+		}
+	}
+
+	off := int(c.pos % int64(c.chunkSize))
+	n := copy(p, c.curChunk[off:])
+	c.pos += int64(n)
+	return n, nil
+}
+
+func (c *chunkedDecryptReader) Seek(offset int64, whence int) (int64, error) {
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = c.pos + offset
+	case io.SeekEnd:
+		newPos = c.totalSize + offset
+	default:
+		return 0, fmt.Errorf("chunkedDecryptReader: invalid whence %d", whence)
+	}
+	if newPos < 0 {
+		return 0, fmt.Errorf("chunkedDecryptReader: negative seek position")
+	}
+	c.pos = newPos
+	return c.pos, nil
+}
+
+// loadChunk decrypts chunk idx into curChunk, extending chunkOffsets as
+// needed by reading only the length prefixes of chunks between the
+// furthest one seen so far and idx.
+func (c *chunkedDecryptReader) loadChunk(idx int) error {
+	for len(c.chunkOffsets) <= idx {
+		last := len(c.chunkOffsets) - 1
+		sealedLen, err := c.readSealedLen(c.chunkOffsets[last])
+		if err != nil {
+			return err
+		}
+		c.chunkOffsets = append(c.chunkOffsets, c.chunkOffsets[last]+4+sealedLen)
+	}
+
+	sealedLen, err := c.readSealedLen(c.chunkOffsets[idx])
+	if err != nil {
+		return err
+	}
+	sealed := make([]byte, sealedLen)
+	if _, err := io.ReadFull(c.rs, sealed); err != nil {
+		return fmt.Errorf("chunk %d: reading sealed bytes: %w", idx, err)
+	}
+
+	nonce := chunkNonce(c.baseNonce, idx)
+	compressed, ok := secretbox.Open(nil, sealed, &nonce, c.key)
+	if !ok {
+		return fmt.Errorf("chunk %d: decryption failed, wrong BLOB_ENCRYPTION_KEY?", idx)
+	}
+	plaintext, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return fmt.Errorf("chunk %d: decompression failed: %w", idx, err)
+	}
+
+	c.curChunk = plaintext
+	c.curIdx = idx
+	c.curValid = true
+	return nil
+}
+
+// readSealedLen seeks rs to offset, reads the 4-byte length prefix there,
+// and leaves rs positioned right after it (i.e. at the start of the sealed
+// chunk bytes), returning the sealed chunk's length.
+func (c *chunkedDecryptReader) readSealedLen(offset int64) (int64, error) {
+	if _, err := c.rs.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(c.rs, lenBuf[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint32(lenBuf[:])), nil
+}
@@ -0,0 +1,142 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/golang/snappy"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// encryptedBlobMagic tags sealed payloads so a misconfigured key (or an
+// attempt to read a pre-existing unencrypted blob) fails loudly instead of
+// returning garbage.
+const encryptedBlobMagic = "PIKAENC1"
+
+const nonceLength = 24
+
+// chunkPlaintextSize is how much plaintext each independently-sealed chunk
+// covers. Sealing (and compressing) in fixed-size chunks, rather than as one
+// monolithic payload, is what lets LoadBlob decrypt only the chunk a Range
+// request actually needs instead of the whole blob.
+const chunkPlaintextSize = 64 * 1024
+
+// encryptedBackend wraps another Backend so every blob is snappy-compressed
+// and sealed with NaCl secretbox before being handed to the inner backend,
+// and reversed on load. Blossom always keys blobs by the plaintext SHA-256
+// (the hash clients computed before upload), so the inner backend's key
+// space doesn't change - only the bytes stored under that key do.
+//
+// On-disk layout: magic (8 bytes) + base nonce (24 bytes) + plaintext total
+// size (8 bytes, big-endian uint64), followed by chunks of
+// [sealed length (4 bytes, big-endian uint32)][sealed chunk bytes]. Chunk i
+// is sealed with the base nonce's last 8 bytes overwritten by i, so nonces
+// never repeat within a blob while still letting LoadBlob seek straight to
+// any chunk.
+type encryptedBackend struct {
+	inner Backend
+	key   [32]byte
+}
+
+func newEncryptedBackend(inner Backend, hexKey string) (Backend, error) {
+	raw, err := hex.DecodeString(hexKey)
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("BLOB_ENCRYPTION_KEY must be a 64-character hex-encoded 32-byte key")
+	}
+
+	var key [32]byte
+	copy(key[:], raw)
+	return &encryptedBackend{inner: inner, key: key}, nil
+}
+
+// chunkNonce derives chunk idx's nonce from a blob's random base nonce.
+func chunkNonce(base [nonceLength]byte, idx int) [nonceLength]byte {
+	nonce := base
+	binary.BigEndian.PutUint64(nonce[nonceLength-8:], uint64(idx))
+	return nonce
+}
+
+func (e *encryptedBackend) StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error {
+	var baseNonce [nonceLength]byte
+	if _, err := rand.Read(baseNonce[:]); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(body)+64))
+	buf.WriteString(encryptedBlobMagic)
+	buf.Write(baseNonce[:])
+	var totalSize [8]byte
+	binary.BigEndian.PutUint64(totalSize[:], uint64(len(body)))
+	buf.Write(totalSize[:])
+
+	for offset := 0; offset < len(body); offset += chunkPlaintextSize {
+		end := offset + chunkPlaintextSize
+		if end > len(body) {
+			end = len(body)
+		}
+		idx := offset / chunkPlaintextSize
+		nonce := chunkNonce(baseNonce, idx)
+
+		compressed := snappy.Encode(nil, body[offset:end])
+		sealed := secretbox.Seal(nil, compressed, &nonce, &e.key)
+
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(sealed)))
+		buf.Write(lenBuf[:])
+		buf.Write(sealed)
+	}
+
+	return e.inner.StoreBlob(ctx, sha256, ext, buf.Bytes())
+}
+
+// LoadBlob returns a reader that decrypts one chunk at a time as it's read,
+// so a caller using http.ServeContent can still serve byte-range requests
+// without the whole decrypted blob living in memory at once - the same
+// guarantee localBackend.LoadBlob gives unencrypted blobs.
+func (e *encryptedBackend) LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+	rs, _, err := e.inner.LoadBlob(ctx, sha256, ext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make([]byte, len(encryptedBlobMagic)+nonceLength+8)
+	if _, err := io.ReadFull(rs, header); err != nil {
+		return nil, nil, fmt.Errorf("blob %s: reading encrypted header: %w", sha256, err)
+	}
+	if string(header[:len(encryptedBlobMagic)]) != encryptedBlobMagic {
+		return nil, nil, fmt.Errorf("blob %s: not a recognized encrypted payload", sha256)
+	}
+
+	var baseNonce [nonceLength]byte
+	copy(baseNonce[:], header[len(encryptedBlobMagic):len(encryptedBlobMagic)+nonceLength])
+	totalSize := int64(binary.BigEndian.Uint64(header[len(encryptedBlobMagic)+nonceLength:]))
+
+	chunk0Offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("blob %s: %w", sha256, err)
+	}
+
+	return newChunkedDecryptReader(rs, &e.key, baseNonce, totalSize, chunkPlaintextSize, chunk0Offset), nil, nil
+}
+
+func (e *encryptedBackend) DeleteBlob(ctx context.Context, sha256 string, ext string) error {
+	return e.inner.DeleteBlob(ctx, sha256, ext)
+}
+
+// ListBlobs delegates to the wrapped backend if it supports Lister. The
+// reported sizes are of the compressed and sealed on-disk form, not the
+// original plaintext - good enough for orphan GC, which only cares about
+// identity and age.
+func (e *encryptedBackend) ListBlobs(ctx context.Context) ([]BlobInfo, error) {
+	lister, ok := e.inner.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("inner backend does not support listing blobs")
+	}
+	return lister.ListBlobs(ctx)
+}
@@ -0,0 +1,87 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// gcsBackend stores blobs as objects in a Google Cloud Storage bucket,
+// keyed by their SHA-256 hash (optionally under GCS_PREFIX).
+type gcsBackend struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func newGCSBackend() (Backend, error) {
+	bucket := envOr("GCS_BUCKET", "")
+	if bucket == "" {
+		return nil, fmt.Errorf("GCS_BUCKET is required when BLOB_BACKEND=gcs")
+	}
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("creating GCS client: %w", err)
+	}
+
+	return &gcsBackend{client: client, bucket: bucket, prefix: envOr("GCS_PREFIX", "")}, nil
+}
+
+func (g *gcsBackend) object(sha256 string) *storage.ObjectHandle {
+	return g.client.Bucket(g.bucket).Object(g.prefix + sha256)
+}
+
+func (g *gcsBackend) StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error {
+	w := g.object(sha256).NewWriter(ctx)
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (g *gcsBackend) LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+	r, err := g.object(sha256).NewReader(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(data), nil, nil
+}
+
+func (g *gcsBackend) DeleteBlob(ctx context.Context, sha256 string, ext string) error {
+	return g.object(sha256).Delete(ctx)
+}
+
+// ListBlobs implements blobstore.Lister by iterating the bucket under
+// prefix and recovering each blob's sha256 by stripping it back off.
+func (g *gcsBackend) ListBlobs(ctx context.Context) ([]BlobInfo, error) {
+	var blobs []BlobInfo
+
+	it := g.client.Bucket(g.bucket).Objects(ctx, &storage.Query{Prefix: g.prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sha256 := strings.TrimPrefix(attrs.Name, g.prefix)
+		blobs = append(blobs, BlobInfo{SHA256: sha256, Size: attrs.Size, ModTime: attrs.Updated})
+	}
+	return blobs, nil
+}
@@ -0,0 +1,104 @@
+package blobstore
+
+import (
+	"context"
+	cryptosha256 "crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localBackend stores blobs as plain files on the local filesystem, keyed
+// by their SHA-256 hash. This is the historical pika behavior.
+type localBackend struct {
+	dir string
+}
+
+func newLocalBackend(dir string) (Backend, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &localBackend{dir: dir}, nil
+}
+
+// StoreBlob writes body to a temp file alongside the target path, fsyncs
+// it, and only renames it into place once the hash computed from body
+// matches what the caller claims - a torn or mismatched write never
+// becomes visible under the final sha256 path.
+//
+// The blossom.StoreBlob hook signature takes body as a []byte, so the
+// upload is already fully buffered in memory by the time it reaches us -
+// that's not a constraint this package can lift. This only fixes the
+// on-disk half: no more holding the write open under its final name while
+// it's still being written, and no second in-memory copy of it.
+func (l *localBackend) StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error {
+	final := filepath.Join(l.dir, sha256)
+
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	sum := cryptosha256.Sum256(body)
+	if got := hex.EncodeToString(sum[:]); got != sha256 {
+		return fmt.Errorf("blob hash mismatch: expected %s, computed %s", sha256, got)
+	}
+
+	return os.Rename(tmpPath, final)
+}
+
+// LoadBlob opens the blob file directly rather than reading it fully into
+// memory, so a caller using http.ServeContent can serve byte-range requests
+// without the file ever being buffered in full.
+func (l *localBackend) LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+	f, err := os.Open(filepath.Join(l.dir, sha256))
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, nil, nil
+}
+
+func (l *localBackend) DeleteBlob(ctx context.Context, sha256 string, ext string) error {
+	return os.Remove(filepath.Join(l.dir, sha256))
+}
+
+// ListBlobs implements Lister by walking dir directly - local storage has
+// no other index of what it holds.
+func (l *localBackend) ListBlobs(ctx context.Context) ([]BlobInfo, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []BlobInfo
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue // skip StoreBlob's in-flight .upload-* temp files
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, BlobInfo{SHA256: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return blobs, nil
+}
@@ -0,0 +1,109 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Backend stores blobs as objects in an S3-compatible bucket, keyed by
+// their SHA-256 hash (optionally under S3_PREFIX).
+type s3Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Backend() (Backend, error) {
+	bucket := envOr("S3_BUCKET", "")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET is required when BLOB_BACKEND=s3")
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := envOr("S3_ENDPOINT", ""); endpoint != "" {
+			// Supports S3-compatible backends (MinIO, R2, etc).
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &s3Backend{client: client, bucket: bucket, prefix: envOr("S3_PREFIX", "")}, nil
+}
+
+func (s *s3Backend) key(sha256 string) string {
+	return s.prefix + sha256
+}
+
+func (s *s3Backend) StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(sha256)),
+		Body:   bytes.NewReader(body),
+	})
+	return err
+}
+
+func (s *s3Backend) LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(sha256)),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(data), nil, nil
+}
+
+func (s *s3Backend) DeleteBlob(ctx context.Context, sha256 string, ext string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(sha256)),
+	})
+	return err
+}
+
+// ListBlobs implements blobstore.Lister by paginating the bucket under
+// prefix and recovering each blob's sha256 by stripping it back off.
+func (s *s3Backend) ListBlobs(ctx context.Context) ([]BlobInfo, error) {
+	var blobs []BlobInfo
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			sha256 := strings.TrimPrefix(aws.ToString(obj.Key), s.prefix)
+			var modTime time.Time
+			if obj.LastModified != nil {
+				modTime = *obj.LastModified
+			}
+			blobs = append(blobs, BlobInfo{SHA256: sha256, Size: aws.ToInt64(obj.Size), ModTime: modTime})
+		}
+	}
+	return blobs, nil
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SampleDiskUsage periodically walks dir, summing regular file sizes, and
+// records the total under DiskUsageBytes{dir=label}. This covers both
+// plain media directories and LMDB environment directories (whose size is
+// just their data.mdb file), similar to how syncthing's infra services
+// poll disk measurements. It runs until stop is closed.
+func SampleDiskUsage(label, dir string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sample := func() {
+		var size int64
+		filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+		DiskUsageBytes.WithLabelValues(label).Set(float64(size))
+	}
+
+	sample()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			sample()
+		}
+	}
+}
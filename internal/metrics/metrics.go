@@ -0,0 +1,67 @@
+// Package metrics defines the Prometheus instruments pika exposes on
+// /metrics: event and blob accounting, subscription/latency tracking, and
+// periodic disk usage sampling for its data directories.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	EventsAccepted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pika_events_accepted_total",
+		Help: "Nostr events accepted, labeled by kind.",
+	}, []string{"kind"})
+
+	EventsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pika_events_rejected_total",
+		Help: "Nostr events rejected, labeled by kind.",
+	}, []string{"kind"})
+
+	ActiveSubscriptions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pika_active_subscriptions",
+		Help: "Number of currently open REQ subscriptions being resolved.",
+	})
+
+	ReqLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pika_req_latency_seconds",
+		Help:    "Time to fully resolve a REQ subscription.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	BlobUploadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pika_blob_upload_bytes_total",
+		Help: "Total bytes accepted via Blossom uploads.",
+	})
+
+	BlobDownloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pika_blob_download_bytes_total",
+		Help: "Total size of blobs loaded for Blossom downloads (full blob size, even for range requests).",
+	})
+
+	BlobUploadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pika_blob_upload_duration_seconds",
+		Help:    "Time to store an uploaded blob.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	BlobDownloadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pika_blob_download_duration_seconds",
+		Help:    "Time to load a blob for download.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	DiskUsageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pika_disk_usage_bytes",
+		Help: "On-disk size of pika-managed directories, labeled by dir.",
+	}, []string{"dir"})
+
+	// RateLimitHits counts rate-limit rejections, enforced per-pubkey by
+	// main's rateLimiter. Not labeled by pubkey: that's an unbounded-
+	// cardinality value and would blow up Prometheus's series count.
+	RateLimitHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pika_rate_limit_hits_total",
+		Help: "Rate limit rejections.",
+	})
+)
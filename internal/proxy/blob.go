@@ -0,0 +1,166 @@
+// Package proxy implements pull-through mirroring of blobs and events from
+// configured upstream relays/Blossom servers, turning a pika instance into
+// a caching edge node in front of them.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	cryptosha256 "crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sledtools/pika/internal/cache"
+)
+
+// BlobBackend wraps an inner blobstore.Backend (any type satisfying its
+// three methods) with pull-through mirroring: a miss on LoadBlob is
+// fetched in full from the configured upstream Blossom servers, returned to
+// the caller immediately, and persisted locally in the background under a
+// TTL that Touch renews on every subsequent hit.
+type BlobBackend struct {
+	Inner interface {
+		StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error
+		LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error)
+		DeleteBlob(ctx context.Context, sha256 string, ext string) error
+	}
+	Upstreams  []string // base URLs of upstream Blossom servers
+	Scheduler  *cache.Scheduler
+	MaxBytes   int64
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	cachedBytes int64
+	sizes       map[string]int64 // sha256 -> size of what we last persisted, so DeleteBlob/eviction can free quota
+}
+
+// NewBlobBackend constructs a pull-through wrapper. upstreams are tried in
+// order on a cache miss.
+func NewBlobBackend(inner interface {
+	StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error
+	LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error)
+	DeleteBlob(ctx context.Context, sha256 string, ext string) error
+}, upstreams []string, sched *cache.Scheduler, maxBytes int64) *BlobBackend {
+	return &BlobBackend{
+		Inner:      inner,
+		Upstreams:  upstreams,
+		Scheduler:  sched,
+		MaxBytes:   maxBytes,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		sizes:      make(map[string]int64),
+	}
+}
+
+func (b *BlobBackend) StoreBlob(ctx context.Context, sha256 string, ext string, body []byte) error {
+	if err := b.Inner.StoreBlob(ctx, sha256, ext, body); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.cachedBytes += int64(len(body)) - b.sizes[sha256]
+	b.sizes[sha256] = int64(len(body))
+	b.mu.Unlock()
+
+	if b.Scheduler != nil {
+		_ = b.Scheduler.Touch(cache.KindBlob, sha256)
+	}
+	return nil
+}
+
+func (b *BlobBackend) cacheUsage() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cachedBytes
+}
+
+// LoadBlob serves from the local backend when present, renewing its TTL.
+// On a miss it pulls the blob from the first upstream that has it, verifies
+// the fetched body actually hashes to sha256 before trusting it at all, then
+// returns it to the caller and persists it locally in the background so the
+// next request is a local hit.
+func (b *BlobBackend) LoadBlob(ctx context.Context, sha256 string, ext string) (io.ReadSeeker, *url.URL, error) {
+	if rs, u, err := b.Inner.LoadBlob(ctx, sha256, ext); err == nil {
+		if b.Scheduler != nil {
+			_ = b.Scheduler.Touch(cache.KindBlob, sha256)
+		}
+		return rs, u, nil
+	}
+
+	for _, upstream := range b.Upstreams {
+		body, err := b.fetchUpstream(ctx, upstream, sha256, ext)
+		if err != nil {
+			continue
+		}
+
+		sum := cryptosha256.Sum256(body)
+		if got := hex.EncodeToString(sum[:]); got != sha256 {
+			log.Printf("pull-through: upstream %s served content for %s that hashes to %s, discarding", upstream, sha256, got)
+			continue
+		}
+
+		if b.MaxBytes <= 0 || b.cacheUsage() < b.MaxBytes {
+			go func() {
+				storeCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				if err := b.StoreBlob(storeCtx, sha256, ext, body); err != nil {
+					log.Printf("pull-through: failed to persist blob %s from %s: %v", sha256, upstream, err)
+				}
+			}()
+		}
+
+		return bytes.NewReader(body), nil, nil
+	}
+
+	return nil, nil, fmt.Errorf("blob %s not found locally or upstream", sha256)
+}
+
+func (b *BlobBackend) DeleteBlob(ctx context.Context, sha256 string, ext string) error {
+	if b.Scheduler != nil {
+		_ = b.Scheduler.Forget(cache.KindBlob, sha256)
+	}
+
+	b.mu.Lock()
+	b.cachedBytes -= b.sizes[sha256]
+	delete(b.sizes, sha256)
+	b.mu.Unlock()
+
+	return b.Inner.DeleteBlob(ctx, sha256, ext)
+}
+
+func (b *BlobBackend) fetchUpstream(ctx context.Context, base, sha256, ext string) ([]byte, error) {
+	reqURL := base + "/" + sha256 + ext
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream %s returned %s", base, resp.Status)
+	}
+
+	var limit int64 = b.MaxBytes
+	if limit <= 0 {
+		limit = 1 << 30 // 1GB sanity ceiling when CACHE_MAX_BYTES is unset
+	}
+	if cl := resp.Header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil && n > limit {
+			return nil, fmt.Errorf("upstream %s blob exceeds cache limit", base)
+		}
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
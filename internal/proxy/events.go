@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+
+	"github.com/sledtools/pika/internal/cache"
+)
+
+// EventQuerier adds pull-through mirroring for REQ filters. The relay's own
+// eventstore-backed QueryEvents hook (wired via relay.UseEventstore) already
+// serves whatever Store has locally, so EventQuerier only contributes the
+// upstream fallback: on a local miss it queries the configured upstream
+// relays, streams back whatever they return, and asynchronously persists it
+// into Store so later requests for the same events are served locally.
+type EventQuerier struct {
+	Store     eventstore.Store
+	Upstreams []string
+	Scheduler *cache.Scheduler
+}
+
+// Query implements khatru's QueryEvents extension point signature. It never
+// forwards Store's own events to the subscriber - that's the local hook's
+// job - it only uses Store to decide whether this filter is a local miss.
+func (q *EventQuerier) Query(ctx context.Context, filter nostr.Filter) (chan *nostr.Event, error) {
+	ch := make(chan *nostr.Event)
+	go func() {
+		defer close(ch)
+
+		if len(q.Upstreams) == 0 || q.hasLocal(ctx, filter) {
+			return
+		}
+
+		for _, upstream := range q.Upstreams {
+			relay, err := nostr.RelayConnect(ctx, upstream)
+			if err != nil {
+				continue
+			}
+
+			events, err := relay.QuerySync(ctx, filter)
+			relay.Close()
+			if err != nil || len(events) == 0 {
+				continue
+			}
+
+			for _, evt := range events {
+				ch <- evt
+				q.persist(evt)
+			}
+			return
+		}
+	}()
+
+	return ch, nil
+}
+
+// hasLocal reports whether Store already has anything matching filter,
+// without forwarding those events anywhere - it's purely a miss check.
+func (q *EventQuerier) hasLocal(ctx context.Context, filter nostr.Filter) bool {
+	probe := filter
+	probe.Limit = 1
+
+	local, err := q.Store.QueryEvents(ctx, probe)
+	if err != nil {
+		return false
+	}
+
+	found := false
+	for range local {
+		found = true
+	}
+	return found
+}
+
+func (q *EventQuerier) persist(evt *nostr.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := q.Store.SaveEvent(ctx, evt); err != nil {
+		log.Printf("pull-through: failed to persist event %s: %v", evt.ID, err)
+		return
+	}
+	if q.Scheduler != nil {
+		_ = q.Scheduler.Touch(cache.KindEvent, evt.ID.Hex())
+	}
+}